@@ -0,0 +1,161 @@
+// Package acme issues and renews TLS certificates for neko-rooms itself,
+// so operators don't have to preconfigure a Traefik certresolver before
+// HTTPS works. It obtains certificates via DNS-01 (so it works for the
+// wildcard TraefikDomain behind the room path prefixes) and writes them
+// out as a Traefik dynamic file-provider config.
+package acme
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/lego"
+	"github.com/go-acme/lego/v4/providers/dns"
+	"github.com/go-acme/lego/v4/registration"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"m1k1o/neko_rooms/internal/config"
+)
+
+// renewBefore is how long before expiry a certificate is renewed.
+const renewBefore = 30 * 24 * time.Hour
+
+func New(config *config.Acme) (*ManagerCtx, error) {
+	logger := log.With().Str("module", "acme").Logger()
+
+	store := newStorage(config.Storage)
+
+	account, err := store.loadOrCreateAccount(config.Email)
+	if err != nil {
+		return nil, fmt.Errorf("acme: unable to load account: %w", err)
+	}
+
+	legoConfig := lego.NewConfig(account)
+	legoConfig.CADirURL = config.CAServer
+	legoConfig.Certificate.KeyType = certcrypto.EC256
+
+	client, err := lego.NewClient(legoConfig)
+	if err != nil {
+		return nil, fmt.Errorf("acme: unable to create client: %w", err)
+	}
+
+	provider, err := dns.NewDNSChallengeProviderByName(config.DNSProvider)
+	if err != nil {
+		return nil, fmt.Errorf("acme: unable to create DNS-01 provider %q: %w", config.DNSProvider, err)
+	}
+
+	if err := client.Challenge.SetDNS01Provider(provider); err != nil {
+		return nil, fmt.Errorf("acme: unable to set DNS-01 provider: %w", err)
+	}
+
+	if account.Registration == nil {
+		reg, err := client.Registration.Register(registration.RegisterOptions{TermsOfServiceAgreed: true})
+		if err != nil {
+			return nil, fmt.Errorf("acme: unable to register account: %w", err)
+		}
+
+		account.Registration = reg
+		if err := store.saveAccount(account); err != nil {
+			return nil, fmt.Errorf("acme: unable to persist account: %w", err)
+		}
+	}
+
+	manager := &ManagerCtx{
+		logger: logger,
+		config: config,
+		store:  store,
+		client: client,
+	}
+
+	// By default the first certificate is obtained synchronously, so New()
+	// fails fast if the DNS-01 provider is misconfigured instead of
+	// silently serving plain HTTP. With OnDemand set, issuance is deferred
+	// to the background renewLoop instead: New() returns immediately and
+	// the dynamic config/cert files show up once DNS propagation and
+	// validation complete, which can take minutes depending on provider.
+	if !config.OnDemand {
+		if err := manager.obtainAndWrite(); err != nil {
+			return nil, err
+		}
+	}
+
+	go manager.renewLoop()
+
+	return manager, nil
+}
+
+type ManagerCtx struct {
+	logger    zerolog.Logger
+	config    *config.Acme
+	store     *storage
+	client    *lego.Client
+	expiresAt time.Time
+}
+
+// Domain returns the domain this manager issues certificates for.
+func (manager *ManagerCtx) Domain() string {
+	return manager.config.Domains[0]
+}
+
+// renewLoop wakes up periodically and re-issues the certificate once it
+// is within renewBefore of expiring, without requiring a neko-rooms
+// restart. If New() deferred the initial issuance (config.OnDemand),
+// manager.expiresAt is still the zero value, which is always due for
+// renewal, so the loop's first pass, which runs right away before any
+// tick, doubles as that deferred issuance.
+func (manager *ManagerCtx) renewLoop() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		if time.Now().Before(manager.expiresAt.Add(-renewBefore)) {
+			<-ticker.C
+			continue
+		}
+
+		if err := manager.obtainAndWrite(); err != nil {
+			manager.logger.Warn().Err(err).Msg("certificate issuance/renewal failed, will retry on next tick")
+		}
+
+		<-ticker.C
+	}
+}
+
+func (manager *ManagerCtx) obtainAndWrite() error {
+	request := certificate.ObtainRequest{
+		Domains: manager.config.Domains,
+		Bundle:  true,
+	}
+
+	cert, err := manager.client.Certificate.Obtain(request)
+	if err != nil {
+		return fmt.Errorf("acme: unable to obtain certificate: %w", err)
+	}
+
+	leaf, err := certcrypto.ParsePEMCertificate(cert.Certificate)
+	if err != nil {
+		return fmt.Errorf("acme: unable to parse issued certificate: %w", err)
+	}
+	manager.expiresAt = leaf.NotAfter
+
+	domain := manager.config.Domains[0]
+
+	if err := manager.store.saveCertificate(domain, cert); err != nil {
+		return fmt.Errorf("acme: unable to persist certificate: %w", err)
+	}
+
+	certPath, keyPath, err := manager.store.writeCertificateFiles(domain, manager.config.CertDir, cert)
+	if err != nil {
+		return fmt.Errorf("acme: unable to write certificate files: %w", err)
+	}
+
+	if err := writeTraefikDynamicConfig(manager.config.TraefikDynamicFile, domain, certPath, keyPath); err != nil {
+		return fmt.Errorf("acme: unable to write traefik dynamic config: %w", err)
+	}
+
+	manager.logger.Info().Str("domain", domain).Time("expires_at", manager.expiresAt).Msg("certificate issued/renewed")
+	return nil
+}
@@ -0,0 +1,184 @@
+package acme
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/registration"
+)
+
+// storedAccount/storedCertificate are the on-disk shapes kept in the
+// acme.json storage file, analogous to Traefik's own acme.json.
+type storedAccount struct {
+	Email        string                 `json:"email"`
+	Registration *registration.Resource `json:"registration"`
+	PrivateKey   []byte                 `json:"privateKey"`
+}
+
+type storedCertificate struct {
+	Domain      string `json:"domain"`
+	Certificate []byte `json:"certificate"`
+	PrivateKey  []byte `json:"privateKey"`
+}
+
+type storageFile struct {
+	Account      *storedAccount      `json:"account"`
+	Certificates []storedCertificate `json:"certificates"`
+}
+
+var errUnsupportedKeyType = errors.New("acme: unsupported account key type")
+
+// storage persists the ACME account and issued certificates to a single
+// JSON file so that renewals survive restarts without re-issuing.
+type storage struct {
+	mu   sync.Mutex
+	path string
+}
+
+func newStorage(path string) *storage {
+	return &storage{path: path}
+}
+
+func (s *storage) load() (storageFile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var file storageFile
+
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return file, nil
+	} else if err != nil {
+		return file, err
+	}
+
+	if len(data) == 0 {
+		return file, nil
+	}
+
+	return file, json.Unmarshal(data, &file)
+}
+
+func (s *storage) save(file storageFile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.path, data, 0600)
+}
+
+func (s *storage) loadOrCreateAccount(email string) (*user, error) {
+	file, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	if file.Account != nil && file.Account.Email == email {
+		key, err := x509.ParseECPrivateKey(file.Account.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+
+		return &user{
+			Email:        file.Account.Email,
+			Registration: file.Account.Registration,
+			key:          key,
+		}, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user{Email: email, key: key}, nil
+}
+
+func (s *storage) saveAccount(u *user) error {
+	file, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	key, ok := u.key.(*ecdsa.PrivateKey)
+	if !ok {
+		return errUnsupportedKeyType
+	}
+
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	file.Account = &storedAccount{
+		Email:        u.Email,
+		Registration: u.Registration,
+		PrivateKey:   der,
+	}
+
+	return s.save(file)
+}
+
+func (s *storage) saveCertificate(domain string, cert *certificate.Resource) error {
+	file, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	entry := storedCertificate{
+		Domain:      domain,
+		Certificate: cert.Certificate,
+		PrivateKey:  cert.PrivateKey,
+	}
+
+	replaced := false
+	for i, existing := range file.Certificates {
+		if existing.Domain == domain {
+			file.Certificates[i] = entry
+			replaced = true
+			break
+		}
+	}
+
+	if !replaced {
+		file.Certificates = append(file.Certificates, entry)
+	}
+
+	return s.save(file)
+}
+
+func (s *storage) certificatePEMPaths(domain, certDir string) (certPath, keyPath string) {
+	return certDir + "/" + domain + ".crt", certDir + "/" + domain + ".key"
+}
+
+// writeCertificateFiles writes the leaf+chain and key out as PEM files
+// under certDir, since Traefik's file provider reads certs off disk
+// rather than out of our JSON storage.
+func (s *storage) writeCertificateFiles(domain, certDir string, cert *certificate.Resource) (string, string, error) {
+	certPath, keyPath := s.certificatePEMPaths(domain, certDir)
+
+	if err := os.MkdirAll(certDir, 0700); err != nil {
+		return "", "", err
+	}
+
+	if err := os.WriteFile(certPath, cert.Certificate, 0600); err != nil {
+		return "", "", err
+	}
+
+	if err := os.WriteFile(keyPath, cert.PrivateKey, 0600); err != nil {
+		return "", "", err
+	}
+
+	return certPath, keyPath, nil
+}
@@ -0,0 +1,31 @@
+package acme
+
+import (
+	"fmt"
+	"os"
+)
+
+// writeTraefikDynamicConfig emits a Traefik file-provider fragment
+// exposing the certificate we just (re)issued, so Traefik picks it up on
+// its usual watch interval without neko-rooms needing a certresolver.
+func writeTraefikDynamicConfig(path, domain, certPath, keyPath string) error {
+	if path == "" {
+		return nil
+	}
+
+	contents := fmt.Sprintf(`# managed by neko-rooms internal/acme for %s, do not edit by hand
+tls:
+  certificates:
+    - certFile: %s
+      keyFile: %s
+      stores:
+        - default
+  stores:
+    default:
+      defaultCertificate:
+        certFile: %s
+        keyFile: %s
+`, domain, certPath, keyPath, certPath, keyPath)
+
+	return os.WriteFile(path, []byte(contents), 0644)
+}
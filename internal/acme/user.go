@@ -0,0 +1,27 @@
+package acme
+
+import (
+	"crypto"
+
+	"github.com/go-acme/lego/v4/registration"
+)
+
+// user implements lego's registration.User, the account identity used to
+// talk to the ACME server on behalf of the operator.
+type user struct {
+	Email        string                 `json:"email"`
+	Registration *registration.Resource `json:"registration"`
+	key          crypto.PrivateKey
+}
+
+func (u *user) GetEmail() string {
+	return u.Email
+}
+
+func (u *user) GetRegistration() *registration.Resource {
+	return u.Registration
+}
+
+func (u *user) GetPrivateKey() crypto.PrivateKey {
+	return u.key
+}
@@ -0,0 +1,78 @@
+// Package api wires room.RoomManager into HTTP handlers. It is kept
+// separate from internal/room so the manager itself stays transport
+// agnostic - everything here is a thin adapter around methods the room
+// package already exposes.
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+
+	"m1k1o/neko_rooms/internal/room"
+	"m1k1o/neko_rooms/internal/types"
+)
+
+// eventSubscriber is implemented by room managers that publish a live
+// lifecycle event stream (currently only RoomManagerCtx, via its Docker
+// events watcher). It's declared here rather than added to
+// room.RoomManager because Swarm/other backends have no equivalent yet.
+type eventSubscriber interface {
+	Subscribe() (<-chan types.RoomEvent, func())
+}
+
+// RoomEventsHandler streams room lifecycle events (created, started, died,
+// destroyed, health_status) to the client over SSE, so the frontend can
+// react to state changes without polling List(). If the underlying
+// manager doesn't support subscriptions, it reports 501 rather than
+// silently returning an empty stream. A RoomManagerCtx on a backend with
+// no events watcher (Podman) does accept the subscription, but its
+// stream never carries any events.
+func RoomEventsHandler(manager room.RoomManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		subscriber, ok := manager.(eventSubscriber)
+		if !ok {
+			http.Error(w, "room events are not supported by this backend", http.StatusNotImplemented)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming not supported", http.StatusInternalServerError)
+			return
+		}
+
+		events, unsubscribe := subscriber.Subscribe()
+		defer unsubscribe()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+
+				data, err := json.Marshal(event)
+				if err != nil {
+					log.Warn().Err(err).Msg("unable to marshal room event")
+					continue
+				}
+
+				if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+}
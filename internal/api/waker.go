@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"m1k1o/neko_rooms/internal/room"
+)
+
+// waker is implemented by room managers that support suspend/resume
+// (currently only RoomManagerCtx, when a store is configured).
+type waker interface {
+	Wake(name string) (string, error)
+}
+
+// WakerMiddleware intercepts requests for a room's path (/<roomName>/...)
+// and re-materializes it via Wake() before handing the request on to next
+// (the reverse-proxy request handler), so a cold room comes back up
+// transparently on the first hit instead of 502'ing. It's a no-op
+// passthrough for backends that don't support waking rooms.
+func WakerMiddleware(manager room.RoomManager, next http.Handler) http.Handler {
+	waker, ok := manager.(waker)
+	if !ok {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		roomName := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)[0]
+		if roomName == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if _, err := waker.Wake(roomName); err != nil {
+			// most common case by far: the room isn't suspended (it's
+			// already running, or doesn't exist), which isn't an error
+			// from the caller's point of view - just let the request
+			// through to whatever would normally handle it
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		log.Info().Str("room", roomName).Msg("woke suspended room for incoming request")
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Acme configures neko-rooms' built-in ACME issuance for TraefikDomain,
+// used in place of (or alongside) a preconfigured Traefik certresolver.
+// Room.Acme is only set (non-nil) once acme_email is provided.
+type Acme struct {
+	Email              string
+	Domains            []string
+	CAServer           string
+	Storage            string
+	OnDemand           bool
+	DNSProvider        string
+	CertDir            string
+	TraefikDynamicFile string
+}
+
+func (Acme) Init(cmd *cobra.Command) error {
+	cmd.PersistentFlags().String("acme_email", "", "contact email for the ACME account; enables built-in certificate issuance when set")
+	if err := viper.BindPFlag("acme_email", cmd.PersistentFlags().Lookup("acme_email")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().StringSlice("acme_domains", []string{}, "domains to request a certificate for (defaults to traefik_domain)")
+	if err := viper.BindPFlag("acme_domains", cmd.PersistentFlags().Lookup("acme_domains")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("acme_caserver", "https://acme-v02.api.letsencrypt.org/directory", "ACME directory URL; point at the staging endpoint to test without hitting rate limits")
+	if err := viper.BindPFlag("acme_caserver", cmd.PersistentFlags().Lookup("acme_caserver")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("acme_storage", "acme.json", "file ACME account/certificate state is persisted to")
+	if err := viper.BindPFlag("acme_storage", cmd.PersistentFlags().Lookup("acme_storage")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Bool("acme_ondemand", false, "defer the first certificate issuance to the background renew loop instead of blocking startup")
+	if err := viper.BindPFlag("acme_ondemand", cmd.PersistentFlags().Lookup("acme_ondemand")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("acme_dns_provider", "", "lego DNS-01 provider name (e.g. cloudflare, route53)")
+	if err := viper.BindPFlag("acme_dns_provider", cmd.PersistentFlags().Lookup("acme_dns_provider")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("acme_cert_dir", "./certs", "directory issued certificate/key PEM files are written to")
+	if err := viper.BindPFlag("acme_cert_dir", cmd.PersistentFlags().Lookup("acme_cert_dir")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("acme_traefik_dynamic_file", "./traefik-dynamic.yaml", "traefik dynamic file-provider config written out alongside the issued certificate")
+	return viper.BindPFlag("acme_traefik_dynamic_file", cmd.PersistentFlags().Lookup("acme_traefik_dynamic_file"))
+}
+
+func (s *Acme) Set() {
+	s.Email = viper.GetString("acme_email")
+	s.Domains = viper.GetStringSlice("acme_domains")
+	if len(s.Domains) == 0 {
+		s.Domains = []string{viper.GetString("traefik_domain")}
+	}
+
+	s.CAServer = viper.GetString("acme_caserver")
+	s.Storage = viper.GetString("acme_storage")
+	s.OnDemand = viper.GetBool("acme_ondemand")
+	s.DNSProvider = viper.GetString("acme_dns_provider")
+	s.CertDir = viper.GetString("acme_cert_dir")
+	s.TraefikDynamicFile = viper.GetString("acme_traefik_dynamic_file")
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Proxy configures the pluggable reverse-proxy label/config generation
+// (see internal/proxy.Provider).
+type Proxy struct {
+	// Kind selects the provider: "traefik-v2" (default), "traefik-v1", or
+	// "file" (Caddy/nginx).
+	Kind string
+
+	// FileDir/FileFormat/ReloadURL/ReloadPID only apply to Kind=="file".
+	FileDir    string
+	FileFormat string
+	ReloadURL  string
+	ReloadPID  int
+}
+
+func (Proxy) Init(cmd *cobra.Command) error {
+	cmd.PersistentFlags().String("proxy_kind", "traefik-v2", "reverse proxy label scheme: traefik-v2, traefik-v1, file")
+	if err := viper.BindPFlag("proxy_kind", cmd.PersistentFlags().Lookup("proxy_kind")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("proxy_file_dir", "", "directory to write per-room config fragments to, when proxy_kind=file")
+	if err := viper.BindPFlag("proxy_file_dir", cmd.PersistentFlags().Lookup("proxy_file_dir")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("proxy_file_format", "caddy", "fragment format to write, when proxy_kind=file: caddy, nginx")
+	if err := viper.BindPFlag("proxy_file_format", cmd.PersistentFlags().Lookup("proxy_file_format")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("proxy_reload_url", "", "admin API URL to POST to after writing a fragment, when proxy_kind=file")
+	if err := viper.BindPFlag("proxy_reload_url", cmd.PersistentFlags().Lookup("proxy_reload_url")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Int("proxy_reload_pid", 0, "PID to send SIGHUP to after writing a fragment, when proxy_kind=file")
+	return viper.BindPFlag("proxy_reload_pid", cmd.PersistentFlags().Lookup("proxy_reload_pid"))
+}
+
+func (s *Proxy) Set() {
+	s.Kind = viper.GetString("proxy_kind")
+	s.FileDir = viper.GetString("proxy_file_dir")
+	s.FileFormat = viper.GetString("proxy_file_format")
+	s.ReloadURL = viper.GetString("proxy_reload_url")
+	s.ReloadPID = viper.GetInt("proxy_reload_pid")
+}
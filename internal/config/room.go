@@ -0,0 +1,143 @@
+// Package config declares neko-rooms' configuration surface and binds it
+// to CLI flags (with environment variable equivalents) via cobra/viper,
+// the same pattern neko itself uses for its own config packages.
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Room configures how rooms are materialized: which container engine to
+// use, how the WebRTC EPR port range is carved up, how rooms are exposed
+// through the reverse proxy, and whether they persist/idle-suspend.
+type Room struct {
+	// Backend selects the container engine: "docker" (default) or
+	// "podman". SwarmMode further swaps the docker backend for Swarm
+	// services when set.
+	Backend      string
+	PodmanSocket string
+	SwarmMode    bool
+	// NodeConstraint is passed straight through to a Swarm service's
+	// Placement.Constraints (e.g. "node.labels.region==eu").
+	NodeConstraint string
+
+	NAT1To1IPs []string
+	EprMin     int
+	EprMax     int
+
+	TraefikDomain       string
+	TraefikNetwork      string
+	TraefikEntrypoint   string
+	TraefikCertresolver string
+
+	Proxy Proxy
+	Acme  *Acme
+
+	// StorePath, if set, enables room persistence (a BoltDB file at this
+	// path); IdleTimeout on top of that enables idle auto-suspend.
+	StorePath   string
+	IdleTimeout time.Duration
+}
+
+func (Room) Init(cmd *cobra.Command) error {
+	cmd.PersistentFlags().String("backend", "docker", "container backend to use for rooms: docker, podman")
+	if err := viper.BindPFlag("backend", cmd.PersistentFlags().Lookup("backend")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("podman_socket", "", "libpod REST API socket, required when backend=podman")
+	if err := viper.BindPFlag("podman_socket", cmd.PersistentFlags().Lookup("podman_socket")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Bool("swarm_mode", false, "manage rooms as Docker Swarm services instead of standalone containers")
+	if err := viper.BindPFlag("swarm_mode", cmd.PersistentFlags().Lookup("swarm_mode")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("node_constraint", "", "swarm placement constraint for room services (e.g. node.labels.region==eu)")
+	if err := viper.BindPFlag("node_constraint", cmd.PersistentFlags().Lookup("node_constraint")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().StringSlice("nat1to1", []string{}, "NAT1TO1 IPs passed through to each room")
+	if err := viper.BindPFlag("nat1to1", cmd.PersistentFlags().Lookup("nat1to1")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Int("epr_min", 59000, "lower bound of the WebRTC EPR port range")
+	if err := viper.BindPFlag("epr_min", cmd.PersistentFlags().Lookup("epr_min")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Int("epr_max", 59100, "upper bound of the WebRTC EPR port range")
+	if err := viper.BindPFlag("epr_max", cmd.PersistentFlags().Lookup("epr_max")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("traefik_domain", "", "domain rooms are exposed under")
+	if err := viper.BindPFlag("traefik_domain", cmd.PersistentFlags().Lookup("traefik_domain")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("traefik_network", "traefik", "docker network shared with the Traefik instance")
+	if err := viper.BindPFlag("traefik_network", cmd.PersistentFlags().Lookup("traefik_network")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("traefik_entrypoint", "websecure", "traefik entrypoint rooms are routed through")
+	if err := viper.BindPFlag("traefik_entrypoint", cmd.PersistentFlags().Lookup("traefik_entrypoint")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("traefik_certresolver", "", "preconfigured traefik certresolver to request room TLS certs from")
+	if err := viper.BindPFlag("traefik_certresolver", cmd.PersistentFlags().Lookup("traefik_certresolver")); err != nil {
+		return err
+	}
+
+	if err := (Proxy{}).Init(cmd); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().String("store_path", "", "BoltDB file to persist room state in; required for idle auto-suspend")
+	if err := viper.BindPFlag("store_path", cmd.PersistentFlags().Lookup("store_path")); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Duration("idle_timeout", 0, "suspend a room's container after this long with no active sessions (0 disables)")
+	if err := viper.BindPFlag("idle_timeout", cmd.PersistentFlags().Lookup("idle_timeout")); err != nil {
+		return err
+	}
+
+	return Acme{}.Init(cmd)
+}
+
+func (s *Room) Set() {
+	s.Backend = viper.GetString("backend")
+	s.PodmanSocket = viper.GetString("podman_socket")
+	s.SwarmMode = viper.GetBool("swarm_mode")
+	s.NodeConstraint = viper.GetString("node_constraint")
+
+	s.NAT1To1IPs = viper.GetStringSlice("nat1to1")
+	s.EprMin = viper.GetInt("epr_min")
+	s.EprMax = viper.GetInt("epr_max")
+
+	s.TraefikDomain = viper.GetString("traefik_domain")
+	s.TraefikNetwork = viper.GetString("traefik_network")
+	s.TraefikEntrypoint = viper.GetString("traefik_entrypoint")
+	s.TraefikCertresolver = viper.GetString("traefik_certresolver")
+
+	s.Proxy.Set()
+
+	if email := viper.GetString("acme_email"); email != "" {
+		acme := &Acme{}
+		acme.Set()
+		s.Acme = acme
+	}
+
+	s.StorePath = viper.GetString("store_path")
+	s.IdleTimeout = viper.GetDuration("idle_timeout")
+}
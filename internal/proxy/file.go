@@ -0,0 +1,131 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"m1k1o/neko_rooms/internal/config"
+	"m1k1o/neko_rooms/internal/types"
+)
+
+// NewFile targets non-Traefik proxies (Caddy, nginx) that read their
+// routes off a config file dropped into a mounted directory, rather than
+// from container labels. Each room gets its own fragment file named after
+// it, so RemoveRoom can delete just that file without reparsing the rest.
+func NewFile(config *config.Room) (*File, error) {
+	if err := os.MkdirAll(config.Proxy.FileDir, 0755); err != nil {
+		return nil, fmt.Errorf("proxy: unable to create %s: %w", config.Proxy.FileDir, err)
+	}
+
+	return &File{config: config}, nil
+}
+
+type File struct {
+	config *config.Room
+	mu     sync.Mutex
+}
+
+func (p *File) RoomLabels(containerName, roomName string, port int, epr types.EprRange) map[string]string {
+	var contents string
+	switch p.config.Proxy.FileFormat {
+	case "nginx":
+		contents = p.nginxServerBlock(containerName, roomName, port)
+	default:
+		contents = p.caddyfileFragment(containerName, roomName, port)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := os.WriteFile(p.fragmentPath(roomName), []byte(contents), 0644); err != nil {
+		// RoomLabels has no error return (it mirrors the Traefik
+		// providers, which can't fail either), so surface write
+		// failures the same way a damaged label would be noticed: the
+		// room simply won't be reachable until the operator investigates
+		return map[string]string{}
+	}
+
+	p.reload()
+
+	// no container/service labels are needed, the proxy reads routing
+	// straight from the fragment file written above
+	return map[string]string{}
+}
+
+func (p *File) RemoveRoom(roomName string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := os.Remove(p.fragmentPath(roomName)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return p.reload()
+}
+
+// fragmentPath is keyed on roomName (not containerName) so RemoveRoom,
+// which only ever receives the bare room name, can find the same file
+// RoomLabels wrote.
+func (p *File) fragmentPath(roomName string) string {
+	ext := "conf"
+	if p.config.Proxy.FileFormat != "nginx" {
+		ext = "caddy"
+	}
+
+	return filepath.Join(p.config.Proxy.FileDir, roomName+"."+ext)
+}
+
+func (p *File) caddyfileFragment(containerName, roomName string, port int) string {
+	return fmt.Sprintf(`%s/%s* {
+	reverse_proxy %s:%d
+}
+`, p.config.TraefikDomain, roomName, containerName, port)
+}
+
+func (p *File) nginxServerBlock(containerName, roomName string, port int) string {
+	return fmt.Sprintf(`location /%s/ {
+	proxy_pass http://%s:%d/;
+	proxy_set_header Upgrade $http_upgrade;
+	proxy_set_header Connection "upgrade";
+}
+`, roomName, containerName, port)
+}
+
+// reload nudges the proxy to pick up the fragment we just wrote/removed.
+// Caddy and nginx both reload their config gracefully on SIGHUP when run
+// as the container's PID 1; operators using an admin API instead can set
+// Proxy.ReloadURL and we'll hit that instead.
+func (p *File) reload() error {
+	if p.config.Proxy.ReloadURL != "" {
+		return reloadViaAdminAPI(p.config.Proxy.ReloadURL)
+	}
+
+	if p.config.Proxy.ReloadPID == 0 {
+		return nil
+	}
+
+	process, err := os.FindProcess(p.config.Proxy.ReloadPID)
+	if err != nil {
+		return err
+	}
+
+	return process.Signal(syscall.SIGHUP)
+}
+
+func reloadViaAdminAPI(url string) error {
+	resp, err := http.Post(url, "application/json", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("proxy: reload endpoint returned %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+// Package proxy extracts the reverse-proxy-specific parts of exposing a
+// room (label grammar, or static config file generation) out of
+// internal/room, so operators aren't stuck with whatever proxy
+// neko-rooms originally hard-coded for.
+package proxy
+
+import (
+	"fmt"
+
+	"m1k1o/neko_rooms/internal/config"
+	"m1k1o/neko_rooms/internal/types"
+)
+
+// Provider abstracts how a room becomes reachable through whatever
+// reverse proxy sits in front of neko-rooms.
+type Provider interface {
+	// RoomLabels returns the labels (container or service labels, for
+	// the Traefik providers) that expose the room at /<roomName>/, with
+	// the UDP epr range routed for WebRTC media where the proxy needs to
+	// know about it at all. containerName is only used to namespace the
+	// generated label/router/service/middleware keys so they don't
+	// collide between rooms; roomName is what must appear in routing
+	// rules, redirects and the advertised room URL.
+	RoomLabels(containerName, roomName string, port int, epr types.EprRange) map[string]string
+
+	// RemoveRoom performs whatever provider-side cleanup isn't already
+	// handled by removing the room's container/service (e.g. rewriting a
+	// static config file and reloading the proxy). It's keyed on the
+	// same roomName RoomLabels was called with.
+	RemoveRoom(roomName string) error
+}
+
+// New returns the Provider selected by config.Room.Proxy.Kind
+// (traefik-v1, traefik-v2, or file). Defaults to traefik-v2, matching
+// neko-rooms' historical label scheme.
+func New(config *config.Room) (Provider, error) {
+	switch config.Proxy.Kind {
+	case "", "traefik-v2":
+		return NewTraefikV2(config), nil
+	case "traefik-v1":
+		return NewTraefikV1(config), nil
+	case "file":
+		return NewFile(config)
+	default:
+		return nil, fmt.Errorf("unknown proxy kind %q", config.Proxy.Kind)
+	}
+}
@@ -0,0 +1,52 @@
+package proxy
+
+import (
+	"fmt"
+
+	"m1k1o/neko_rooms/internal/config"
+	"m1k1o/neko_rooms/internal/types"
+)
+
+// NewTraefikV1 targets the older Traefik v1 label grammar
+// (traefik.frontend.*/traefik.backend.*), for operators who haven't
+// migrated their reverse proxy yet.
+func NewTraefikV1(config *config.Room) *TraefikV1 {
+	return &TraefikV1{config: config}
+}
+
+type TraefikV1 struct {
+	config *config.Room
+}
+
+func (p *TraefikV1) RoomLabels(containerName, roomName string, port int, epr types.EprRange) map[string]string {
+	rule := fmt.Sprintf("Host:%s;PathPrefix:/%s", p.config.TraefikDomain, roomName)
+
+	labels := map[string]string{
+		"traefik.enable":                                   "true",
+		"traefik.docker.network":                           p.config.TraefikNetwork,
+		"traefik.port":                                     fmt.Sprintf("%d", port),
+		"traefik.frontend.rule":                            rule,
+		"traefik.frontend.passHostHeader":                  "true",
+		"traefik.frontend.redirect.regex":                  "^(.*)/" + roomName + "$",
+		"traefik.frontend.redirect.replacement":            "$1/" + roomName + "/",
+		"traefik.frontend.auth.forward.trustForwardHeader": "true",
+	}
+
+	if p.config.TraefikEntrypoint != "" {
+		labels["traefik.frontend.entryPoints"] = p.config.TraefikEntrypoint
+	}
+
+	if p.config.TraefikCertresolver != "" {
+		// v1 has no certresolver concept; TLS is a frontend-wide entrypoint
+		// setting, so the best we can do per-router is require it
+		labels["traefik.frontend.headers.forceSTSHeader"] = "true"
+	}
+
+	return labels
+}
+
+// RemoveRoom is a no-op: Traefik v1's Docker provider also watches
+// container events directly and deregisters the frontend on its own.
+func (p *TraefikV1) RemoveRoom(roomName string) error {
+	return nil
+}
@@ -0,0 +1,51 @@
+package proxy
+
+import (
+	"fmt"
+
+	"m1k1o/neko_rooms/internal/config"
+	"m1k1o/neko_rooms/internal/types"
+)
+
+// NewTraefikV2 targets Traefik v2's router/service/middleware label
+// grammar. This is the label scheme neko-rooms always used before the
+// proxy became pluggable, kept as the default.
+func NewTraefikV2(config *config.Room) *TraefikV2 {
+	return &TraefikV2{config: config}
+}
+
+type TraefikV2 struct {
+	config *config.Room
+}
+
+func (p *TraefikV2) RoomLabels(containerName, roomName string, port int, epr types.EprRange) map[string]string {
+	labels := map[string]string{
+		"traefik.enable": "true",
+		"traefik.http.services." + containerName + "-frontend.loadbalancer.server.port": fmt.Sprintf("%d", port),
+		"traefik.http.routers." + containerName + ".entrypoints":                        p.config.TraefikEntrypoint,
+		"traefik.http.routers." + containerName + ".rule":                               "Host(`" + p.config.TraefikDomain + "`) && PathPrefix(`/" + roomName + "`)",
+		"traefik.http.middlewares." + containerName + "-rdr.redirectregex.regex":        "/" + roomName + "$$",
+		"traefik.http.middlewares." + containerName + "-rdr.redirectregex.replacement":  "/" + roomName + "/",
+		"traefik.http.middlewares." + containerName + "-prf.stripprefix.prefixes":       "/" + roomName + "/",
+		"traefik.http.routers." + containerName + ".middlewares":                        containerName + "-rdr," + containerName + "-prf",
+	}
+
+	// TLS is available either via a preconfigured certresolver, or via
+	// our own acme-issued certificate served through Traefik's file
+	// provider (internal/acme writes that dynamic config separately)
+	if p.config.TraefikCertresolver != "" || p.config.Acme != nil {
+		labels["traefik.http.routers."+containerName+".tls"] = "true"
+
+		if p.config.TraefikCertresolver != "" {
+			labels["traefik.http.routers."+containerName+".tls.certresolver"] = p.config.TraefikCertresolver
+		}
+	}
+
+	return labels
+}
+
+// RemoveRoom is a no-op: Traefik's Docker provider drops routers for us
+// as soon as the labelled container/service disappears.
+func (p *TraefikV2) RemoveRoom(roomName string) error {
+	return nil
+}
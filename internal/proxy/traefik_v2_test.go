@@ -0,0 +1,38 @@
+package proxy
+
+import (
+	"testing"
+
+	"m1k1o/neko_rooms/internal/config"
+	"m1k1o/neko_rooms/internal/types"
+)
+
+func TestTraefikV2RoomLabelsUsesBareRoomNameInRoutingValues(t *testing.T) {
+	p := NewTraefikV2(&config.Room{
+		TraefikDomain:     "rooms.example.com",
+		TraefikEntrypoint: "websecure",
+	})
+
+	containerName := "neko-room-myroom"
+	roomName := "myroom"
+	epr := types.EprRange{Min: 59000, Max: 59010}
+
+	labels := p.RoomLabels(containerName, roomName, 8080, epr)
+
+	rule := labels["traefik.http.routers."+containerName+".rule"]
+	wantRule := "Host(`rooms.example.com`) && PathPrefix(`/myroom`)"
+	if rule != wantRule {
+		t.Fatalf("rule = %q, want %q", rule, wantRule)
+	}
+
+	prefix := labels["traefik.http.middlewares."+containerName+"-prf.stripprefix.prefixes"]
+	if prefix != "/myroom/" {
+		t.Fatalf("stripprefix = %q, want %q", prefix, "/myroom/")
+	}
+
+	// the generated keys must be namespaced by the (unique) container
+	// name, not the room name, so two rooms never collide
+	if _, ok := labels["traefik.http.routers."+roomName+".rule"]; ok {
+		t.Fatalf("label keys must use containerName, found a key keyed on bare roomName")
+	}
+}
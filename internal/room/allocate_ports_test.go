@@ -0,0 +1,130 @@
+package room
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+
+	"m1k1o/neko_rooms/internal/config"
+	"m1k1o/neko_rooms/internal/types"
+)
+
+// fakeBackend is a ContainerBackend that only ever needs to answer
+// ContainerList for these tests.
+type fakeBackend struct {
+	containers []BackendContainer
+}
+
+func (f *fakeBackend) ContainerList(ctx context.Context) ([]BackendContainer, error) {
+	return f.containers, nil
+}
+
+func (f *fakeBackend) ContainerInspect(ctx context.Context, id string) (BackendContainer, error) {
+	panic("not used by this test")
+}
+
+func (f *fakeBackend) ContainerCreate(ctx context.Context, name string, cfg *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig) (string, error) {
+	panic("not used by this test")
+}
+
+func (f *fakeBackend) ContainerStart(ctx context.Context, id string) error {
+	panic("not used by this test")
+}
+func (f *fakeBackend) ContainerStop(ctx context.Context, id string) error {
+	panic("not used by this test")
+}
+func (f *fakeBackend) ContainerRestart(ctx context.Context, id string) error {
+	panic("not used by this test")
+}
+func (f *fakeBackend) ContainerRemove(ctx context.Context, id string) error {
+	panic("not used by this test")
+}
+
+func newTestManager(t *testing.T, containers []BackendContainer) *RoomManagerCtx {
+	t.Helper()
+
+	s, err := newStore(filepath.Join(t.TempDir(), "rooms.db"))
+	if err != nil {
+		t.Fatalf("newStore() error = %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	return &RoomManagerCtx{
+		config:  &config.Room{EprMin: 59000, EprMax: 59010},
+		backend: &fakeBackend{containers: containers},
+		store:   s,
+	}
+}
+
+func TestAllocatePortsSkipsRangeHeldByLiveContainer(t *testing.T) {
+	manager := newTestManager(t, []BackendContainer{
+		{Labels: map[string]string{
+			"m1k1o.neko_rooms.epr.min": "59000",
+			"m1k1o.neko_rooms.epr.max": "59003",
+		}},
+	})
+
+	epr, err := manager.allocatePorts(4)
+	if err != nil {
+		t.Fatalf("allocatePorts() error = %v", err)
+	}
+
+	if epr.Min != 59004 {
+		t.Fatalf("epr.Min = %d, want 59004 (first range after the live container's)", epr.Min)
+	}
+}
+
+func TestAllocatePortsSkipsRangeHeldBySuspendedRoom(t *testing.T) {
+	manager := newTestManager(t, nil)
+
+	if err := manager.store.put(roomRecord{
+		Name:       "suspended-room",
+		Epr:        types.EprRange{Min: 59000, Max: 59003},
+		LastActive: time.Now(),
+		Suspended:  true,
+	}); err != nil {
+		t.Fatalf("store.put() error = %v", err)
+	}
+
+	epr, err := manager.allocatePorts(4)
+	if err != nil {
+		t.Fatalf("allocatePorts() error = %v", err)
+	}
+
+	if epr.Min == 59000 {
+		t.Fatalf("allocatePorts() returned %d, which collides with a suspended room's reserved range", epr.Min)
+	}
+	if epr.Min != 59004 {
+		t.Fatalf("epr.Min = %d, want 59004 (first free range after the suspended room's)", epr.Min)
+	}
+}
+
+func TestAllocatePortsIgnoresNonSuspendedRecord(t *testing.T) {
+	manager := newTestManager(t, nil)
+
+	// a non-suspended store record means the room's container should
+	// still exist and show up via listContainers(); its ports must not be
+	// double-reserved here, or a live room's own range would make
+	// allocatePorts think it's already full
+	if err := manager.store.put(roomRecord{
+		Name:       "running-room",
+		Epr:        types.EprRange{Min: 59000, Max: 59003},
+		LastActive: time.Now(),
+		Suspended:  false,
+	}); err != nil {
+		t.Fatalf("store.put() error = %v", err)
+	}
+
+	epr, err := manager.allocatePorts(4)
+	if err != nil {
+		t.Fatalf("allocatePorts() error = %v", err)
+	}
+
+	if epr.Min != 59000 {
+		t.Fatalf("epr.Min = %d, want 59000 (non-suspended records are not reserved here)", epr.Min)
+	}
+}
@@ -0,0 +1,41 @@
+package room
+
+import (
+	"context"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+)
+
+// ContainerBackend abstracts over the underlying container engine so that
+// RoomManagerCtx does not depend on github.com/docker/docker/client
+// directly. This lets rooms be materialized either via the Docker API or
+// via rootless Podman/libpod, selected with config.Room.Backend.
+//
+// The Docker container.Config/HostConfig/NetworkingConfig types are kept
+// as the common currency between RoomManagerCtx and its backends, since
+// they already describe everything a room container needs (env, labels,
+// port bindings, caps, shm size, restart policy); each backend is
+// responsible for translating them into whatever shape its engine wants.
+type ContainerBackend interface {
+	ContainerList(ctx context.Context) ([]BackendContainer, error)
+	ContainerInspect(ctx context.Context, id string) (BackendContainer, error)
+	ContainerCreate(ctx context.Context, name string, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig) (string, error)
+	ContainerStart(ctx context.Context, id string) error
+	ContainerStop(ctx context.Context, id string) error
+	ContainerRestart(ctx context.Context, id string) error
+	ContainerRemove(ctx context.Context, id string) error
+}
+
+// BackendContainer is the subset of container state RoomManagerCtx needs,
+// normalized across backends.
+type BackendContainer struct {
+	ID      string
+	Image   string
+	Labels  map[string]string
+	Env     []string
+	State   string
+	Status  string
+	Created time.Time
+}
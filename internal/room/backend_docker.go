@@ -0,0 +1,100 @@
+package room
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	dockerClient "github.com/docker/docker/client"
+)
+
+// NewDockerBackend wraps an existing Docker client as a ContainerBackend.
+func NewDockerBackend(client *dockerClient.Client) *DockerBackend {
+	return &DockerBackend{client: client}
+}
+
+type DockerBackend struct {
+	client *dockerClient.Client
+}
+
+func (b *DockerBackend) ContainerList(ctx context.Context) ([]BackendContainer, error) {
+	containers, err := b.client.ContainerList(ctx, dockerTypes.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", labelCanary)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]BackendContainer, len(containers))
+	for i, cont := range containers {
+		result[i] = BackendContainer{
+			ID:      cont.ID,
+			Image:   cont.Image,
+			Labels:  cont.Labels,
+			State:   cont.State,
+			Status:  cont.Status,
+			Created: time.Unix(cont.Created, 0),
+		}
+	}
+
+	return result, nil
+}
+
+func (b *DockerBackend) ContainerInspect(ctx context.Context, id string) (BackendContainer, error) {
+	cont, err := b.client.ContainerInspect(ctx, id)
+	if err != nil {
+		return BackendContainer{}, err
+	}
+
+	if cont.Config == nil {
+		return BackendContainer{}, fmt.Errorf("container %s has no config", id)
+	}
+
+	created, err := time.Parse(time.RFC3339Nano, cont.Created)
+	if err != nil {
+		created = time.Time{}
+	}
+
+	return BackendContainer{
+		ID:      cont.ID,
+		Image:   cont.Config.Image,
+		Labels:  cont.Config.Labels,
+		Env:     cont.Config.Env,
+		State:   cont.State.Status,
+		Status:  cont.State.Status,
+		Created: created,
+	}, nil
+}
+
+func (b *DockerBackend) ContainerCreate(ctx context.Context, name string, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig) (string, error) {
+	cont, err := b.client.ContainerCreate(ctx, config, hostConfig, networkingConfig, nil, name)
+	if err != nil {
+		return "", err
+	}
+
+	return cont.ID, nil
+}
+
+func (b *DockerBackend) ContainerStart(ctx context.Context, id string) error {
+	return b.client.ContainerStart(ctx, id, dockerTypes.ContainerStartOptions{})
+}
+
+func (b *DockerBackend) ContainerStop(ctx context.Context, id string) error {
+	return b.client.ContainerStop(ctx, id, nil)
+}
+
+func (b *DockerBackend) ContainerRestart(ctx context.Context, id string) error {
+	return b.client.ContainerRestart(ctx, id, nil)
+}
+
+func (b *DockerBackend) ContainerRemove(ctx context.Context, id string) error {
+	return b.client.ContainerRemove(ctx, id, dockerTypes.ContainerRemoveOptions{
+		RemoveVolumes: true,
+		Force:         true,
+	})
+}
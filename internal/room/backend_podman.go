@@ -0,0 +1,167 @@
+package room
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/containers/podman/v3/pkg/bindings"
+	"github.com/containers/podman/v3/pkg/bindings/containers"
+	"github.com/containers/podman/v3/pkg/specgen"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+)
+
+// NewPodmanBackend connects to a libpod REST API socket (e.g.
+// unix:///run/podman/podman.sock, or the user's rootless equivalent under
+// XDG_RUNTIME_DIR) and returns it as a ContainerBackend. This lets
+// neko-rooms run without a Docker daemon, which matters for rootless
+// WebRTC deployments on locked-down hosts.
+func NewPodmanBackend(socket string) (*PodmanBackend, error) {
+	conn, err := bindings.NewConnection(context.Background(), socket)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to podman socket: %w", err)
+	}
+
+	return &PodmanBackend{conn: conn}, nil
+}
+
+type PodmanBackend struct {
+	conn context.Context
+}
+
+func (b *PodmanBackend) ContainerList(ctx context.Context) ([]BackendContainer, error) {
+	all := true
+	list, err := containers.List(b.conn, &containers.ListOptions{
+		All:     &all,
+		Filters: map[string][]string{"label": {labelCanary}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]BackendContainer, len(list))
+	for i, cont := range list {
+		result[i] = BackendContainer{
+			ID:      cont.ID,
+			Image:   cont.Image,
+			Labels:  cont.Labels,
+			State:   cont.State,
+			Status:  cont.Status,
+			Created: cont.Created,
+		}
+	}
+
+	return result, nil
+}
+
+func (b *PodmanBackend) ContainerInspect(ctx context.Context, id string) (BackendContainer, error) {
+	data, err := containers.Inspect(b.conn, id, nil)
+	if err != nil {
+		return BackendContainer{}, err
+	}
+
+	return BackendContainer{
+		ID:      data.ID,
+		Image:   data.ImageName,
+		Labels:  data.Config.Labels,
+		Env:     data.Config.Env,
+		State:   data.State.Status,
+		Status:  data.State.Status,
+		Created: data.Created,
+	}, nil
+}
+
+// ContainerCreate translates the Docker container.Config/HostConfig pair
+// (env, labels, port bindings, SYS_ADMIN cap, shm size, restart policy)
+// into a podman specgen.SpecGenerator, since libpod has no notion of
+// those Docker API types directly.
+func (b *PodmanBackend) ContainerCreate(ctx context.Context, name string, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig) (string, error) {
+	spec := specgen.NewSpecGenerator(config.Image, false)
+	spec.Name = name
+	spec.Hostname = config.Hostname
+	spec.Env = envToMap(config.Env)
+	spec.Labels = config.Labels
+	spec.CapAdd = []string(hostConfig.CapAdd)
+	spec.ShmSize = &hostConfig.ShmSize
+
+	for containerPort, bindings := range hostConfig.PortBindings {
+		for _, binding := range bindings {
+			spec.PortMappings = append(spec.PortMappings, specPortMapping(containerPort.Int(), binding.HostPort, containerPort.Proto()))
+		}
+	}
+
+	switch hostConfig.RestartPolicy.Name {
+	case "always":
+		spec.RestartPolicy = "always"
+	case "":
+		spec.RestartPolicy = "no"
+	default:
+		spec.RestartPolicy = hostConfig.RestartPolicy.Name
+	}
+
+	spec.Networks = map[string]specgen.NetnsMode{}
+	for network := range networkingConfig.EndpointsConfig {
+		spec.Networks[network] = specgen.NetnsMode{}
+	}
+
+	report, err := containers.CreateWithSpec(b.conn, spec, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return report.ID, nil
+}
+
+func (b *PodmanBackend) ContainerStart(ctx context.Context, id string) error {
+	return containers.Start(b.conn, id, nil)
+}
+
+func (b *PodmanBackend) ContainerStop(ctx context.Context, id string) error {
+	return containers.Stop(b.conn, id, nil)
+}
+
+func (b *PodmanBackend) ContainerRestart(ctx context.Context, id string) error {
+	return containers.Restart(b.conn, id, nil)
+}
+
+func (b *PodmanBackend) ContainerRemove(ctx context.Context, id string) error {
+	force := true
+	volumes := true
+	_, err := containers.Remove(b.conn, id, &containers.RemoveOptions{
+		Force:   &force,
+		Volumes: &volumes,
+	})
+	return err
+}
+
+func envToMap(env []string) map[string]string {
+	result := map[string]string{}
+	for _, kv := range env {
+		for i := 0; i < len(kv); i++ {
+			if kv[i] == '=' {
+				result[kv[:i]] = kv[i+1:]
+				break
+			}
+		}
+	}
+	return result
+}
+
+func specPortMapping(containerPort int, hostPort, proto string) specgen.PortMapping {
+	return specgen.PortMapping{
+		ContainerPort: uint16(containerPort),
+		HostPort:      uint16(atoiOrZero(hostPort)),
+		Protocol:      proto,
+	}
+}
+
+func atoiOrZero(s string) int {
+	n := 0
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return 0
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n
+}
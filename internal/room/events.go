@@ -0,0 +1,200 @@
+package room
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+
+	"m1k1o/neko_rooms/internal/types"
+)
+
+// eventBroker fans out room lifecycle events to any number of
+// subscribers (e.g. one per connected SSE/WebSocket client), so the
+// frontend can render state changes without polling List().
+type eventBroker struct {
+	mu   sync.Mutex
+	next int
+	subs map[int]chan types.RoomEvent
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{
+		subs: map[int]chan types.RoomEvent{},
+	}
+}
+
+// subscribe returns a channel that receives every future room event, and
+// an unsubscribe func that must be called once the consumer is done
+// (e.g. when the SSE client disconnects).
+func (b *eventBroker) subscribe() (<-chan types.RoomEvent, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.next
+	b.next++
+
+	ch := make(chan types.RoomEvent, 16)
+	b.subs[id] = ch
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if ch, ok := b.subs[id]; ok {
+			delete(b.subs, id)
+			close(ch)
+		}
+	}
+}
+
+func (b *eventBroker) publish(event types.RoomEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, ch := range b.subs {
+		select {
+		case ch <- event:
+		default:
+			// slow subscriber, drop the event rather than block the watcher
+		}
+	}
+}
+
+// Subscribe lets callers receive room lifecycle events (created, started,
+// died, destroyed, health_status) as they happen, instead of polling
+// List(). The returned func must be called to release the subscription.
+func (manager *RoomManagerCtx) Subscribe() (<-chan types.RoomEvent, func()) {
+	return manager.events.subscribe()
+}
+
+// watchEvents subscribes to the Docker events stream for containers
+// carrying our canary label and republishes them as typed RoomEvents.
+// Docker closes the events stream on daemon restart, so this reconnects
+// with backoff. Each connection is bracketed by ContainerList snapshots:
+// a room running when the connection opened, with no event of its own
+// during it, and no longer running once it closed gets a synthetic died
+// event. Other transitions missed while disconnected are not replayed.
+func (manager *RoomManagerCtx) watchEvents(backend *DockerBackend) {
+	backoff := time.Second
+
+	for {
+		known, err := manager.snapshotRunning()
+		if err != nil {
+			manager.logger.Warn().Err(err).Msg("unable to resync room state before watching events")
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		msgs, errs := backend.client.Events(ctx, dockerTypes.EventsOptions{
+			Filters: filters.NewArgs(filters.Arg("label", labelCanary)),
+		})
+
+		manager.logger.Info().Msg("subscribed to docker events")
+
+		for done := false; !done; {
+			select {
+			case msg, ok := <-msgs:
+				if !ok {
+					done = true
+					break
+				}
+
+				// the stream is delivering again, so the next reconnect
+				// starts over from the shortest delay
+				backoff = time.Second
+
+				event, ok := toRoomEvent(msg)
+				if !ok {
+					continue
+				}
+
+				delete(known, event.ID)
+				manager.events.publish(event)
+			case err, ok := <-errs:
+				if ok && err != nil {
+					manager.logger.Warn().Err(err).Msg("docker events stream closed, reconnecting")
+				}
+				done = true
+			}
+		}
+
+		cancel()
+
+		// anything still in `known` didn't get an explicit event during
+		// this connection - but the stream also closes on an ordinary idle
+		// disconnect, not just because the room actually died, so resync
+		// against a fresh ContainerList before reporting anything: only
+		// rooms that are truly gone now get a synthetic died event. If the
+		// resync itself fails, don't guess - skip reporting deaths rather
+		// than risk flagging healthy rooms as died.
+		stillRunning, err := manager.snapshotRunning()
+		if err != nil {
+			manager.logger.Warn().Err(err).Msg("unable to resync room state after events stream closed")
+			stillRunning = known
+		}
+
+		for id, event := range known {
+			if _, ok := stillRunning[id]; ok {
+				continue
+			}
+
+			event.Kind = types.RoomEventDied
+			manager.events.publish(event)
+		}
+
+		time.Sleep(backoff)
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+// snapshotRunning returns the currently running rooms keyed by container
+// ID, used to detect transitions missed while the events stream was down.
+func (manager *RoomManagerCtx) snapshotRunning() (map[string]types.RoomEvent, error) {
+	containers, err := manager.listContainers()
+	if err != nil {
+		return nil, err
+	}
+
+	known := map[string]types.RoomEvent{}
+	for _, cont := range containers {
+		if cont.State != "running" {
+			continue
+		}
+
+		known[cont.ID] = types.RoomEvent{
+			ID:   cont.ID,
+			Name: cont.Labels["m1k1o.neko_rooms.name"],
+			Kind: types.RoomEventStarted,
+		}
+	}
+
+	return known, nil
+}
+
+func toRoomEvent(msg dockerTypes.Message) (types.RoomEvent, bool) {
+	var kind types.RoomEventKind
+	switch msg.Action {
+	case "create":
+		kind = types.RoomEventCreated
+	case "start":
+		kind = types.RoomEventStarted
+	case "die":
+		kind = types.RoomEventDied
+	case "destroy":
+		kind = types.RoomEventDestroyed
+	case "health_status: healthy", "health_status: unhealthy":
+		kind = types.RoomEventHealthStatus
+	default:
+		return types.RoomEvent{}, false
+	}
+
+	return types.RoomEvent{
+		ID:   msg.Actor.ID,
+		Name: msg.Actor.Attributes["m1k1o.neko_rooms.name"],
+		Kind: kind,
+	}, true
+}
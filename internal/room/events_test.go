@@ -0,0 +1,68 @@
+package room
+
+import (
+	"testing"
+	"time"
+
+	"m1k1o/neko_rooms/internal/types"
+)
+
+func TestEventBrokerPublishFansOutToAllSubscribers(t *testing.T) {
+	broker := newEventBroker()
+
+	ch1, unsub1 := broker.subscribe()
+	defer unsub1()
+	ch2, unsub2 := broker.subscribe()
+	defer unsub2()
+
+	want := types.RoomEvent{ID: "abc", Name: "myroom", Kind: types.RoomEventStarted}
+	broker.publish(want)
+
+	for _, ch := range []<-chan types.RoomEvent{ch1, ch2} {
+		select {
+		case got := <-ch:
+			if got != want {
+				t.Fatalf("got %+v, want %+v", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for published event")
+		}
+	}
+}
+
+func TestEventBrokerUnsubscribeClosesChannelAndStopsDelivery(t *testing.T) {
+	broker := newEventBroker()
+
+	ch, unsub := broker.subscribe()
+	unsub()
+
+	if _, ok := <-ch; ok {
+		t.Fatal("channel should be closed after unsubscribe")
+	}
+
+	// publishing after the only subscriber left must not block or panic
+	broker.publish(types.RoomEvent{ID: "abc", Kind: types.RoomEventDied})
+}
+
+func TestEventBrokerDropsEventsForSlowSubscriberInsteadOfBlocking(t *testing.T) {
+	broker := newEventBroker()
+
+	_, unsub := broker.subscribe()
+	defer unsub()
+
+	done := make(chan struct{})
+	go func() {
+		// the subscriber's buffered channel (size 16) is never drained
+		// here; publish must not block on it
+		for i := 0; i < 100; i++ {
+			broker.publish(types.RoomEvent{ID: "abc", Kind: types.RoomEventStarted})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publish() blocked on a slow/unread subscriber channel")
+	}
+}
@@ -0,0 +1,172 @@
+package room
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// watchIdle periodically checks every known room's activity and removes
+// the container (but keeps the store record) once it has been idle past
+// config.IdleTimeout. A later call to Wake() re-materializes it.
+func (manager *RoomManagerCtx) watchIdle() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := manager.suspendIdleRooms(); err != nil {
+			manager.logger.Warn().Err(err).Msg("idle check failed")
+		}
+	}
+}
+
+func (manager *RoomManagerCtx) suspendIdleRooms() error {
+	records, err := manager.store.all()
+	if err != nil {
+		return err
+	}
+
+	containers, err := manager.listContainers()
+	if err != nil {
+		return err
+	}
+
+	byName := map[string]BackendContainer{}
+	for _, cont := range containers {
+		byName[cont.Labels["m1k1o.neko_rooms.name"]] = cont
+	}
+
+	for name, record := range records {
+		if record.Suspended {
+			continue
+		}
+
+		cont, running := byName[name]
+		if !running {
+			// already gone some other way (e.g. manually removed); nothing
+			// for the idle watcher to do. Stopped or exited containers are
+			// still listed and go through the idle check below.
+			continue
+		}
+
+		active, err := manager.activeSessions(name)
+		if err != nil {
+			manager.logger.Debug().Err(err).Str("room", name).Msg("unable to read session count, falling back to last-active timestamp")
+		}
+
+		if active > 0 {
+			if err := manager.store.touch(name); err != nil {
+				manager.logger.Warn().Err(err).Str("room", name).Msg("unable to update last-active timestamp")
+			}
+			continue
+		}
+
+		if time.Since(record.LastActive) < manager.config.IdleTimeout {
+			continue
+		}
+
+		manager.logger.Info().Str("room", name).Dur("idle_for", time.Since(record.LastActive)).Msg("suspending idle room")
+
+		if err := manager.backend.ContainerRemove(context.Background(), cont.ID); err != nil {
+			manager.logger.Warn().Err(err).Str("room", name).Msg("unable to suspend idle room")
+			continue
+		}
+
+		if err := manager.store.setSuspended(name, true); err != nil {
+			manager.logger.Warn().Err(err).Str("room", name).Msg("unable to persist suspended state")
+		}
+	}
+
+	return nil
+}
+
+// activeSessions asks neko's own HTTP API how many sessions are
+// currently connected. neko-rooms has no direct network path into the
+// room beyond what Traefik exposes, so this talks to the frontend port
+// over the proxy network the container is already attached to.
+func (manager *RoomManagerCtx) activeSessions(name string) (int, error) {
+	url := fmt.Sprintf("http://%s:%d/api/sessions", containerPrefix+name, frontendPort)
+
+	client := http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status from neko session API: %s", resp.Status)
+	}
+
+	var sessions []json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&sessions); err != nil {
+		return 0, err
+	}
+
+	return len(sessions), nil
+}
+
+// wakeReadyTimeout bounds how long Wake() waits for the re-created
+// container to start answering its own HTTP API before giving up. Callers
+// forwarding a request right after Wake() (e.g. the waker middleware) rely
+// on this so they don't proxy into a container that's still booting.
+const wakeReadyTimeout = 15 * time.Second
+
+// Wake re-materializes a suspended room from its stored settings and EPR
+// range, so a request that hits the room's URL while it's cold can bring
+// it back without the caller having to know the original settings. It
+// blocks until the room is actually answering requests (or the timeout
+// elapses), so a caller that forwards a request right after Wake()
+// returns doesn't just trade a suspended-room error for a connection
+// refused/502 against a container that hasn't finished starting yet.
+func (manager *RoomManagerCtx) Wake(name string) (string, error) {
+	if manager.store == nil {
+		return "", fmt.Errorf("room persistence is not enabled")
+	}
+
+	record, found, err := manager.store.get(name)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", fmt.Errorf("no stored room named %q", name)
+	}
+	if !record.Suspended {
+		return "", fmt.Errorf("room %q is not suspended", name)
+	}
+
+	id, err := manager.createFromRecord(record)
+	if err != nil {
+		return "", err
+	}
+
+	if err := manager.store.setSuspended(name, false); err != nil {
+		manager.logger.Warn().Err(err).Str("room", name).Msg("unable to clear suspended state after waking room")
+	}
+
+	if err := manager.waitUntilReady(name, wakeReadyTimeout); err != nil {
+		manager.logger.Warn().Err(err).Str("room", name).Msg("room did not report ready after waking")
+	}
+
+	return id, nil
+}
+
+// waitUntilReady polls the room's own HTTP API until it responds or
+// timeout elapses.
+func (manager *RoomManagerCtx) waitUntilReady(name string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		if _, err := manager.activeSessions(name); err == nil {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("room %q did not become reachable within %s", name, timeout)
+		}
+
+		time.Sleep(250 * time.Millisecond)
+	}
+}
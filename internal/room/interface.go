@@ -0,0 +1,24 @@
+package room
+
+import (
+	"m1k1o/neko_rooms/internal/types"
+)
+
+// RoomManager is implemented by every backend capable of materializing
+// neko rooms (plain Docker containers, Swarm services, ...). The HTTP
+// layer and New() only ever talk to this interface, so a new backend can
+// be added without touching callers.
+type RoomManager interface {
+	List() ([]types.RoomEntry, error)
+	Create(settings types.RoomSettings) (string, error)
+	Get(id string) (*types.RoomSettings, error)
+	Remove(id string) error
+	Start(id string) error
+	Stop(id string) error
+	Restart(id string) error
+}
+
+var (
+	_ RoomManager = &RoomManagerCtx{}
+	_ RoomManager = &SwarmManagerCtx{}
+)
@@ -3,10 +3,10 @@ package room
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
-	dockerTypes "github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	network "github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/api/types/strslice"
@@ -15,7 +15,9 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
+	"m1k1o/neko_rooms/internal/acme"
 	"m1k1o/neko_rooms/internal/config"
+	"m1k1o/neko_rooms/internal/proxy"
 	"m1k1o/neko_rooms/internal/types"
 	"m1k1o/neko_rooms/internal/utils"
 )
@@ -25,29 +27,105 @@ const (
 	containerPrefix = "neko-room-"
 	frontendPort    = 8080
 	labelCanary     = "m1k1o-neko-rooms"
+
+	// suspendedIDPrefix marks the synthetic IDs suspendedEntries() hands
+	// out in List() for rooms that have no container right now. Get/Start/
+	// Stop/Restart/Remove all check for it before touching the backend,
+	// since there's no container for the backend to find.
+	suspendedIDPrefix = "suspended:"
 )
 
-func New(config *config.Room) *RoomManagerCtx {
+// suspendedRoomName reports whether id is one of suspendedEntries()'s
+// synthetic IDs, and if so, the room name it was suspended under.
+func suspendedRoomName(id string) (string, bool) {
+	return strings.CutPrefix(id, suspendedIDPrefix)
+}
+
+func New(config *config.Room) RoomManager {
 	logger := log.With().Str("module", "room").Logger()
 
-	cli, err := dockerClient.NewEnvClient()
+	var backend ContainerBackend
+
+	switch config.Backend {
+	case "podman":
+		podman, err := NewPodmanBackend(config.PodmanSocket)
+		if err != nil {
+			logger.Panic().Err(err).Msg("unable to connect to podman socket")
+		}
+
+		logger.Info().Msg("successfully connected to podman socket")
+		backend = podman
+	case "", "docker":
+		cli, err := dockerClient.NewEnvClient()
+		if err != nil {
+			logger.Panic().Err(err).Msg("unable to connect to docker client")
+		} else {
+			logger.Info().Msg("successfully connected to docker client")
+		}
+
+		if config.SwarmMode {
+			logger.Info().Msg("swarm mode enabled, managing rooms as services")
+			return NewSwarm(config, cli)
+		}
+
+		backend = NewDockerBackend(cli)
+	default:
+		logger.Panic().Str("backend", config.Backend).Msg("unknown room backend")
+	}
+
+	var acmeManager *acme.ManagerCtx
+	if config.Acme != nil {
+		var err error
+		acmeManager, err = acme.New(config.Acme)
+		if err != nil {
+			logger.Panic().Err(err).Msg("unable to set up acme")
+		}
+	}
+
+	proxyProvider, err := proxy.New(config)
 	if err != nil {
-		logger.Panic().Err(err).Msg("unable to connect to docker client")
-	} else {
-		logger.Info().Msg("successfully connected to docker client")
+		logger.Panic().Err(err).Msg("unable to set up proxy provider")
+	}
+
+	var roomStore *store
+	if config.StorePath != "" {
+		roomStore, err = newStore(config.StorePath)
+		if err != nil {
+			logger.Panic().Err(err).Msg("unable to open room store")
+		}
+	}
+
+	manager := &RoomManagerCtx{
+		logger:  logger,
+		config:  config,
+		backend: backend,
+		events:  newEventBroker(),
+		acme:    acmeManager,
+		proxy:   proxyProvider,
+		store:   roomStore,
 	}
 
-	return &RoomManagerCtx{
-		logger: logger,
-		config: config,
-		client: cli,
+	// only the Docker backend can stream lifecycle events today; Podman
+	// support can be added the same way once libpod's event API is wired in
+	if docker, ok := backend.(*DockerBackend); ok {
+		go manager.watchEvents(docker)
 	}
+
+	if roomStore != nil && config.IdleTimeout > 0 {
+		go manager.watchIdle()
+	}
+
+	return manager
 }
 
 type RoomManagerCtx struct {
-	logger zerolog.Logger
-	config *config.Room
-	client *dockerClient.Client
+	logger  zerolog.Logger
+	config  *config.Room
+	backend ContainerBackend
+	events  *eventBroker
+	acme    *acme.ManagerCtx
+	store   *store
+	proxy   proxy.Provider
 }
 
 func (manager *RoomManagerCtx) List() ([]types.RoomEntry, error) {
@@ -81,7 +159,46 @@ func (manager *RoomManagerCtx) List() ([]types.RoomEntry, error) {
 			Image:          container.Image,
 			Running:        container.State == "running",
 			Status:         container.Status,
-			Created:        time.Unix(container.Created, 0),
+			Created:        container.Created,
+		})
+	}
+
+	if manager.store != nil {
+		suspended, err := manager.suspendedEntries()
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, suspended...)
+	}
+
+	return result, nil
+}
+
+// suspendedEntries lists rooms that exist only as store records (their
+// container has been removed by the idle watcher). Their ID is a
+// "suspended:" marker rather than a real container ID since Wake() keys
+// off the room name, not an ID that no longer exists.
+func (manager *RoomManagerCtx) suspendedEntries() ([]types.RoomEntry, error) {
+	records, err := manager.store.all()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []types.RoomEntry{}
+	for name, record := range records {
+		if !record.Suspended {
+			continue
+		}
+
+		result = append(result, types.RoomEntry{
+			ID:             suspendedIDPrefix + name,
+			Name:           name,
+			MaxConnections: record.Epr.Max - record.Epr.Min + 1,
+			Image:          nekoImage,
+			Running:        false,
+			Status:         "suspended",
+			Created:        record.LastActive,
 		})
 	}
 
@@ -98,12 +215,41 @@ func (manager *RoomManagerCtx) Create(settings types.RoomSettings) (string, erro
 			return "", err
 		}
 	}
+	settings.Name = roomName
 
 	epr, err := manager.allocatePorts(settings.MaxConnections)
 	if err != nil {
 		return "", err
 	}
 
+	id, err := manager.createContainer(roomName, epr, settings)
+	if err != nil {
+		return "", err
+	}
+
+	if manager.store != nil {
+		err := manager.store.put(roomRecord{
+			Name:       roomName,
+			Settings:   settings,
+			Epr:        epr,
+			LastActive: time.Now(),
+		})
+		if err != nil {
+			manager.logger.Warn().Err(err).Str("room", roomName).Msg("unable to persist room record")
+		}
+	}
+
+	return id, nil
+}
+
+// createFromRecord re-creates a previously suspended room's container
+// from its stored settings and EPR range, keeping its URL and port
+// allocation stable across suspend/wake cycles.
+func (manager *RoomManagerCtx) createFromRecord(record roomRecord) (string, error) {
+	return manager.createContainer(record.Name, record.Epr, record.Settings)
+}
+
+func (manager *RoomManagerCtx) createContainer(roomName string, epr types.EprRange, settings types.RoomSettings) (string, error) {
 	portBindings := nat.PortMap{}
 	exposedPorts := nat.PortSet{
 		nat.Port(fmt.Sprintf("%d/udp", frontendPort)): struct{}{},
@@ -124,8 +270,13 @@ func (manager *RoomManagerCtx) Create(settings types.RoomSettings) (string, erro
 
 	containerName := containerPrefix + roomName
 
+	// TLS is available either because the operator preconfigured a
+	// Traefik certresolver, or because our own acme manager is issuing
+	// and renewing a certificate for them
+	tlsEnabled := manager.config.TraefikCertresolver != "" || manager.acme != nil
+
 	urlProto := "http"
-	if manager.config.TraefikCertresolver != "" {
+	if tlsEnabled {
 		urlProto = "https"
 	}
 
@@ -136,22 +287,14 @@ func (manager *RoomManagerCtx) Create(settings types.RoomSettings) (string, erro
 		"m1k1o.neko_rooms.canary":  labelCanary,
 		"m1k1o.neko_rooms.epr.min": fmt.Sprintf("%d", epr.Min),
 		"m1k1o.neko_rooms.epr.max": fmt.Sprintf("%d", epr.Max),
-
-		// Set traefik labels
-		"traefik.enable": "true",
-		"traefik.http.services." + containerName + "-frontend.loadbalancer.server.port": fmt.Sprintf("%d", frontendPort),
-		"traefik.http.routers." + containerName + ".entrypoints":                        manager.config.TraefikEntrypoint,
-		"traefik.http.routers." + containerName + ".rule":                               "Host(`" + manager.config.TraefikDomain + "`) && PathPrefix(`/" + roomName + "`)",
-		"traefik.http.middlewares." + containerName + "-rdr.redirectregex.regex":        "/" + roomName + "$$",
-		"traefik.http.middlewares." + containerName + "-rdr.redirectregex.replacement":  "/" + roomName + "/",
-		"traefik.http.middlewares." + containerName + "-prf.stripprefix.prefixes":       "/" + roomName + "/",
-		"traefik.http.routers." + containerName + ".middlewares":                        containerName + "-rdr," + containerName + "-prf",
 	}
 
-	// optional HTTPS
-	if manager.config.TraefikCertresolver != "" {
-		labels["traefik.http.routers."+containerName+".tls"] = "true"
-		labels["traefik.http.routers."+containerName+".tls.certresolver"] = manager.config.TraefikCertresolver
+	// Set the reverse-proxy specific labels (or, for the file provider,
+	// write out a config fragment and return none). containerName is
+	// only used to namespace label/router keys; roomName is what must
+	// show up in the actual routing rule and the advertised room URL.
+	for key, value := range manager.proxy.RoomLabels(containerName, roomName, frontendPort, epr) {
+		labels[key] = value
 	}
 
 	config := &container.Config{
@@ -200,13 +343,12 @@ func (manager *RoomManagerCtx) Create(settings types.RoomSettings) (string, erro
 	}
 
 	// Creating the actual container
-	cont, err := manager.client.ContainerCreate(
+	id, err := manager.backend.ContainerCreate(
 		context.Background(),
+		containerName,
 		config,
 		hostConfig,
 		networkingConfig,
-		nil,
-		containerName,
 	)
 
 	if err != nil {
@@ -214,27 +356,42 @@ func (manager *RoomManagerCtx) Create(settings types.RoomSettings) (string, erro
 	}
 
 	// Start the actual container
-	err = manager.client.ContainerStart(context.Background(), cont.ID, dockerTypes.ContainerStartOptions{})
+	err = manager.backend.ContainerStart(context.Background(), id)
 
 	if err != nil {
 		return "", err
 	}
 
-	return cont.ID, nil
+	return id, nil
 }
 
 func (manager *RoomManagerCtx) Get(id string) (*types.RoomSettings, error) {
+	if roomName, ok := suspendedRoomName(id); ok {
+		record, found, err := manager.store.get(roomName)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fmt.Errorf("no stored room named %q", roomName)
+		}
+
+		settings := record.Settings
+		settings.Name = roomName
+		settings.MaxConnections = record.Epr.Max - record.Epr.Min + 1
+		return &settings, nil
+	}
+
 	container, err := manager.inspectContainer(id)
 	if err != nil {
 		return nil, err
 	}
 
-	roomName, ok := container.Config.Labels["m1k1o.neko_rooms.name"]
+	roomName, ok := container.Labels["m1k1o.neko_rooms.name"]
 	if !ok {
 		return nil, fmt.Errorf("Damaged container labels: name not found.")
 	}
 
-	epr, err := manager.getEprFromLabels(container.Config.Labels)
+	epr, err := manager.getEprFromLabels(container.Labels)
 	if err != nil {
 		return nil, err
 	}
@@ -244,59 +401,180 @@ func (manager *RoomManagerCtx) Get(id string) (*types.RoomSettings, error) {
 		MaxConnections: epr.Max - epr.Min + 1,
 	}
 
-	err = settings.FromEnv(container.Config.Env)
+	err = settings.FromEnv(container.Env)
 	return &settings, err
 }
 
 func (manager *RoomManagerCtx) Remove(id string) error {
-	_, err := manager.inspectContainer(id)
+	if roomName, ok := suspendedRoomName(id); ok {
+		// no container to stop/remove - just drop the stored record and
+		// any proxy-side config left over for it
+		if manager.store != nil {
+			if err := manager.store.delete(roomName); err != nil {
+				manager.logger.Warn().Err(err).Str("room", roomName).Msg("unable to delete room record")
+			}
+		}
+
+		return manager.proxy.RemoveRoom(roomName)
+	}
+
+	cont, err := manager.inspectContainer(id)
 	if err != nil {
 		return err
 	}
 
 	// Stop the actual container
-	err = manager.client.ContainerStop(context.Background(), id, nil)
+	err = manager.backend.ContainerStop(context.Background(), id)
 
 	if err != nil {
 		return err
 	}
 
 	// Remove the actual container
-	err = manager.client.ContainerRemove(context.Background(), id, dockerTypes.ContainerRemoveOptions{
-		RemoveVolumes: true,
-		Force:         true,
-	})
+	if err := manager.backend.ContainerRemove(context.Background(), id); err != nil {
+		return err
+	}
+
+	roomName := cont.Labels["m1k1o.neko_rooms.name"]
 
-	return err
+	// unlike idle-suspend, an explicit Remove() drops the stored record
+	// too: there's nothing left to Wake() back up
+	if manager.store != nil {
+		if err := manager.store.delete(roomName); err != nil {
+			manager.logger.Warn().Err(err).Str("room", roomName).Msg("unable to delete room record")
+		}
+	}
+
+	// Let the proxy provider clean up anything it doesn't already drop on
+	// its own (e.g. a written Caddyfile/nginx fragment)
+	return manager.proxy.RemoveRoom(roomName)
 }
 
 func (manager *RoomManagerCtx) Start(id string) error {
+	// a suspended room has no container left to start - waking it back up
+	// is the equivalent operation
+	if roomName, ok := suspendedRoomName(id); ok {
+		_, err := manager.Wake(roomName)
+		return err
+	}
+
 	_, err := manager.inspectContainer(id)
 	if err != nil {
 		return err
 	}
 
 	// Start the actual container
-	return manager.client.ContainerStart(context.Background(), id, dockerTypes.ContainerStartOptions{})
+	return manager.backend.ContainerStart(context.Background(), id)
 }
 
-
 func (manager *RoomManagerCtx) Stop(id string) error {
+	// already suspended is already stopped, as far as the caller cares
+	if _, ok := suspendedRoomName(id); ok {
+		return nil
+	}
+
 	_, err := manager.inspectContainer(id)
 	if err != nil {
 		return err
 	}
 
 	// Stop the actual container
-	return manager.client.ContainerStop(context.Background(), id, nil)
+	return manager.backend.ContainerStop(context.Background(), id)
 }
 
 func (manager *RoomManagerCtx) Restart(id string) error {
+	// nothing to restart in place - wake it from cold instead
+	if roomName, ok := suspendedRoomName(id); ok {
+		_, err := manager.Wake(roomName)
+		return err
+	}
+
 	_, err := manager.inspectContainer(id)
 	if err != nil {
 		return err
 	}
 
 	// Restart the actual container
-	return manager.client.ContainerRestart(context.Background(), id, nil)
+	return manager.backend.ContainerRestart(context.Background(), id)
+}
+
+func (manager *RoomManagerCtx) listContainers() ([]BackendContainer, error) {
+	return manager.backend.ContainerList(context.Background())
+}
+
+func (manager *RoomManagerCtx) inspectContainer(id string) (BackendContainer, error) {
+	return manager.backend.ContainerInspect(context.Background(), id)
+}
+
+func (manager *RoomManagerCtx) getEprFromLabels(labels map[string]string) (types.EprRange, error) {
+	min, err := strconv.Atoi(labels["m1k1o.neko_rooms.epr.min"])
+	if err != nil {
+		return types.EprRange{}, fmt.Errorf("Damaged container labels: %w", err)
+	}
+
+	max, err := strconv.Atoi(labels["m1k1o.neko_rooms.epr.max"])
+	if err != nil {
+		return types.EprRange{}, fmt.Errorf("Damaged container labels: %w", err)
+	}
+
+	return types.EprRange{Min: min, Max: max}, nil
+}
+
+// allocatePorts finds the first free EPR range of the requested size,
+// scanning ports already claimed by other room containers.
+func (manager *RoomManagerCtx) allocatePorts(maxConnections int) (types.EprRange, error) {
+	containers, err := manager.listContainers()
+	if err != nil {
+		return types.EprRange{}, err
+	}
+
+	taken := map[int]bool{}
+	for _, container := range containers {
+		epr, err := manager.getEprFromLabels(container.Labels)
+		if err != nil {
+			continue
+		}
+
+		for port := epr.Min; port <= epr.Max; port++ {
+			taken[port] = true
+		}
+	}
+
+	// suspended rooms hold no container, but their EPR range is still
+	// reserved for when Wake() recreates them - otherwise a newly created
+	// room could steal the ports out from under a room that's merely
+	// sleeping, and Wake() would fail (or worse, collide) later
+	if manager.store != nil {
+		records, err := manager.store.all()
+		if err != nil {
+			return types.EprRange{}, err
+		}
+
+		for _, record := range records {
+			if !record.Suspended {
+				continue
+			}
+
+			for port := record.Epr.Min; port <= record.Epr.Max; port++ {
+				taken[port] = true
+			}
+		}
+	}
+
+	min, max := manager.config.EprMin, manager.config.EprMax
+	for start := min; start+maxConnections-1 <= max; start++ {
+		free := true
+		for port := start; port < start+maxConnections; port++ {
+			if taken[port] {
+				free = false
+				break
+			}
+		}
+
+		if free {
+			return types.EprRange{Min: start, Max: start + maxConnections - 1}, nil
+		}
+	}
+
+	return types.EprRange{}, fmt.Errorf("no free EPR range of size %d available", maxConnections)
 }
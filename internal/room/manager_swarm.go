@@ -0,0 +1,397 @@
+package room
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/swarm"
+	dockerClient "github.com/docker/docker/client"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"m1k1o/neko_rooms/internal/config"
+	"m1k1o/neko_rooms/internal/types"
+	"m1k1o/neko_rooms/internal/utils"
+)
+
+// NewSwarm returns a RoomManager backed by Docker Swarm services instead
+// of standalone containers. It is selected by New() when config.SwarmMode
+// is set.
+func NewSwarm(config *config.Room, cli *dockerClient.Client) *SwarmManagerCtx {
+	return &SwarmManagerCtx{
+		logger: log.With().Str("module", "room").Str("backend", "swarm").Logger(),
+		config: config,
+		client: cli,
+	}
+}
+
+type SwarmManagerCtx struct {
+	logger zerolog.Logger
+	config *config.Room
+	client *dockerClient.Client
+}
+
+func (manager *SwarmManagerCtx) List() ([]types.RoomEntry, error) {
+	services, err := manager.listServices()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []types.RoomEntry{}
+	for _, service := range services {
+		roomName, ok := service.Spec.Labels["m1k1o.neko_rooms.name"]
+		if !ok {
+			return nil, fmt.Errorf("Damaged service labels: name not found.")
+		}
+
+		URL, ok := service.Spec.Labels["m1k1o.neko_rooms.url"]
+		if !ok {
+			return nil, fmt.Errorf("Damaged service labels: url not found.")
+		}
+
+		epr, err := manager.getEprFromLabels(service.Spec.Labels)
+		if err != nil {
+			return nil, err
+		}
+
+		status := "replicas: ?/?"
+		if service.ServiceStatus != nil {
+			status = fmt.Sprintf("replicas: %d/%d", service.ServiceStatus.RunningTasks, service.ServiceStatus.DesiredTasks)
+		}
+
+		// types.RoomEntry has no dedicated node field, so fold the node the
+		// task actually landed on into Status the same way we already fold
+		// in the replica count - operators need this to point DNS/NAT1To1
+		// at the right host, and there's nowhere else to put it
+		if node := manager.serviceNode(service.ID); node != "" {
+			status += ", node: " + node
+		}
+
+		result = append(result, types.RoomEntry{
+			ID:             service.ID,
+			URL:            URL,
+			Name:           roomName,
+			MaxConnections: epr.Max - epr.Min + 1,
+			Image:          service.Spec.TaskTemplate.ContainerSpec.Image,
+			Running:        service.ServiceStatus != nil && service.ServiceStatus.RunningTasks > 0,
+			Status:         status,
+			Created:        service.CreatedAt,
+		})
+	}
+
+	return result, nil
+}
+
+func (manager *SwarmManagerCtx) Create(settings types.RoomSettings) (string, error) {
+	// TODO: Check if path name exists.
+	roomName := settings.Name
+	if roomName == "" {
+		var err error
+		roomName, err = utils.NewUID(32)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	epr, err := manager.allocatePorts(settings.MaxConnections)
+	if err != nil {
+		return "", err
+	}
+
+	serviceName := containerPrefix + roomName
+
+	urlProto := "http"
+	if manager.config.TraefikCertresolver != "" {
+		urlProto = "https"
+	}
+
+	labels := map[string]string{
+		// Set internal labels
+		"m1k1o.neko_rooms.name":    roomName,
+		"m1k1o.neko_rooms.url":     urlProto + "://" + manager.config.TraefikDomain + "/" + roomName + "/",
+		"m1k1o.neko_rooms.canary":  labelCanary,
+		"m1k1o.neko_rooms.epr.min": fmt.Sprintf("%d", epr.Min),
+		"m1k1o.neko_rooms.epr.max": fmt.Sprintf("%d", epr.Max),
+
+		// Set traefik labels, always in the traefik-v2 grammar. Unlike the
+		// container backend, services do not go through Proxy.Kind, and
+		// TLS labels are only added for a preconfigured certresolver, not
+		// for the built-in acme issuance (which swarm mode does not start)
+		"traefik.enable": "true",
+		"traefik.http.services." + serviceName + "-frontend.loadbalancer.server.port": fmt.Sprintf("%d", frontendPort),
+		"traefik.http.routers." + serviceName + ".entrypoints":                        manager.config.TraefikEntrypoint,
+		"traefik.http.routers." + serviceName + ".rule":                               "Host(`" + manager.config.TraefikDomain + "`) && PathPrefix(`/" + roomName + "`)",
+		"traefik.http.middlewares." + serviceName + "-rdr.redirectregex.regex":        "/" + roomName + "$$",
+		"traefik.http.middlewares." + serviceName + "-rdr.redirectregex.replacement":  "/" + roomName + "/",
+		"traefik.http.middlewares." + serviceName + "-prf.stripprefix.prefixes":       "/" + roomName + "/",
+		"traefik.http.routers." + serviceName + ".middlewares":                        serviceName + "-rdr," + serviceName + "-prf",
+	}
+
+	// optional HTTPS
+	if manager.config.TraefikCertresolver != "" {
+		labels["traefik.http.routers."+serviceName+".tls"] = "true"
+		labels["traefik.http.routers."+serviceName+".tls.certresolver"] = manager.config.TraefikCertresolver
+	}
+
+	// the UDP EPR range must bypass the Swarm ingress mesh (it is not
+	// load-balanced media traffic), so it's published in host mode on
+	// whichever node the task actually lands on
+	var portConfigs []swarm.PortConfig
+	for port := epr.Min; port <= epr.Max; port++ {
+		portConfigs = append(portConfigs, swarm.PortConfig{
+			Protocol:      swarm.PortConfigProtocolUDP,
+			PublishMode:   swarm.PortConfigPublishModeHost,
+			TargetPort:    uint32(port),
+			PublishedPort: uint32(port),
+		})
+	}
+
+	var placement *swarm.Placement
+	if manager.config.NodeConstraint != "" {
+		placement = &swarm.Placement{
+			Constraints: []string{manager.config.NodeConstraint},
+		}
+	}
+
+	replicas := uint64(1)
+
+	spec := swarm.ServiceSpec{
+		Annotations: swarm.Annotations{
+			Name:   serviceName,
+			Labels: labels,
+		},
+		TaskTemplate: swarm.TaskSpec{
+			ContainerSpec: &swarm.ContainerSpec{
+				Image:    nekoImage,
+				Hostname: serviceName,
+				Env: append([]string{
+					fmt.Sprintf("NEKO_BIND=%d", frontendPort),
+					fmt.Sprintf("NEKO_EPR=%d-%d", epr.Min, epr.Max),
+					fmt.Sprintf("NEKO_NAT1TO1=%s", strings.Join(manager.config.NAT1To1IPs, ",")),
+				}, settings.ToEnv()...),
+				CapabilityAdd: []string{
+					"SYS_ADMIN",
+				},
+				// shm size has no dedicated field on ContainerSpec, it's
+				// instead mounted as a sized tmpfs at /dev/shm
+				Mounts: []mount.Mount{
+					{
+						Type:   mount.TypeTmpfs,
+						Target: "/dev/shm",
+						TmpfsOptions: &mount.TmpfsOptions{
+							SizeBytes: 2 * 10e9,
+						},
+					},
+				},
+			},
+			Placement: placement,
+			RestartPolicy: &swarm.RestartPolicy{
+				Condition: swarm.RestartPolicyConditionAny,
+			},
+			Networks: []swarm.NetworkAttachmentConfig{
+				{Target: manager.config.TraefikNetwork},
+			},
+		},
+		Mode: swarm.ServiceMode{
+			Replicated: &swarm.ReplicatedService{
+				Replicas: &replicas,
+			},
+		},
+		EndpointSpec: &swarm.EndpointSpec{
+			Mode:  swarm.ResolutionModeDNSRR,
+			Ports: portConfigs,
+		},
+	}
+
+	resp, err := manager.client.ServiceCreate(context.Background(), spec, dockerTypes.ServiceCreateOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	return resp.ID, nil
+}
+
+func (manager *SwarmManagerCtx) Get(id string) (*types.RoomSettings, error) {
+	service, err := manager.inspectService(id)
+	if err != nil {
+		return nil, err
+	}
+
+	roomName, ok := service.Spec.Labels["m1k1o.neko_rooms.name"]
+	if !ok {
+		return nil, fmt.Errorf("Damaged service labels: name not found.")
+	}
+
+	epr, err := manager.getEprFromLabels(service.Spec.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := types.RoomSettings{
+		Name:           roomName,
+		MaxConnections: epr.Max - epr.Min + 1,
+	}
+
+	err = settings.FromEnv(service.Spec.TaskTemplate.ContainerSpec.Env)
+	return &settings, err
+}
+
+func (manager *SwarmManagerCtx) Remove(id string) error {
+	_, err := manager.inspectService(id)
+	if err != nil {
+		return err
+	}
+
+	return manager.client.ServiceRemove(context.Background(), id)
+}
+
+func (manager *SwarmManagerCtx) Start(id string) error {
+	return manager.scale(id, 1)
+}
+
+func (manager *SwarmManagerCtx) Stop(id string) error {
+	return manager.scale(id, 0)
+}
+
+func (manager *SwarmManagerCtx) Restart(id string) error {
+	service, err := manager.inspectService(id)
+	if err != nil {
+		return err
+	}
+
+	// there is no restart verb for services: force a rolling update with
+	// the unchanged spec so Swarm recreates the task in place. Leaving
+	// ForceUpdate unchanged makes ServiceUpdate a no-op when nothing else in
+	// the spec changed, same as `docker service update` without --force
+	spec := service.Spec
+	spec.TaskTemplate.ForceUpdate++
+
+	_, err = manager.client.ServiceUpdate(
+		context.Background(),
+		service.ID,
+		service.Version,
+		spec,
+		dockerTypes.ServiceUpdateOptions{},
+	)
+
+	return err
+}
+
+// scale sets the desired replica count of the room's service. Stop() maps
+// to scale-to-0 since Swarm has no concept of a stopped-but-present task.
+func (manager *SwarmManagerCtx) scale(id string, replicas uint64) error {
+	service, err := manager.inspectService(id)
+	if err != nil {
+		return err
+	}
+
+	spec := service.Spec
+	if spec.Mode.Replicated == nil {
+		return fmt.Errorf("room service is not running in replicated mode")
+	}
+
+	spec.Mode.Replicated.Replicas = &replicas
+
+	_, err = manager.client.ServiceUpdate(
+		context.Background(),
+		service.ID,
+		service.Version,
+		spec,
+		dockerTypes.ServiceUpdateOptions{},
+	)
+
+	return err
+}
+
+func (manager *SwarmManagerCtx) listServices() ([]swarm.Service, error) {
+	return manager.client.ServiceList(context.Background(), dockerTypes.ServiceListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", labelCanary)),
+		Status:  true,
+	})
+}
+
+// serviceNode returns the hostname of the node the service's (single)
+// running task is currently scheduled on, or "" if no task can be found
+// (none running yet, or the task lookup itself fails - this is
+// informational only and must never fail List()). If the task is found
+// but its node can't be inspected, the bare node ID is returned instead.
+func (manager *SwarmManagerCtx) serviceNode(serviceID string) string {
+	tasks, err := manager.client.TaskList(context.Background(), dockerTypes.TaskListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("service", serviceID),
+			filters.Arg("desired-state", "running"),
+		),
+	})
+	if err != nil || len(tasks) == 0 {
+		return ""
+	}
+
+	node, _, err := manager.client.NodeInspectWithRaw(context.Background(), tasks[0].NodeID)
+	if err != nil {
+		return tasks[0].NodeID
+	}
+
+	return node.Description.Hostname
+}
+
+func (manager *SwarmManagerCtx) inspectService(id string) (*swarm.Service, error) {
+	service, _, err := manager.client.ServiceInspectWithRaw(context.Background(), id, dockerTypes.ServiceInspectOptions{})
+	return &service, err
+}
+
+func (manager *SwarmManagerCtx) getEprFromLabels(labels map[string]string) (types.EprRange, error) {
+	min, err := strconv.Atoi(labels["m1k1o.neko_rooms.epr.min"])
+	if err != nil {
+		return types.EprRange{}, fmt.Errorf("Damaged service labels: %w", err)
+	}
+
+	max, err := strconv.Atoi(labels["m1k1o.neko_rooms.epr.max"])
+	if err != nil {
+		return types.EprRange{}, fmt.Errorf("Damaged service labels: %w", err)
+	}
+
+	return types.EprRange{Min: min, Max: max}, nil
+}
+
+// allocatePorts finds the first free EPR range of the requested size,
+// scanning ports already claimed by other room services.
+func (manager *SwarmManagerCtx) allocatePorts(maxConnections int) (types.EprRange, error) {
+	services, err := manager.listServices()
+	if err != nil {
+		return types.EprRange{}, err
+	}
+
+	taken := map[int]bool{}
+	for _, service := range services {
+		epr, err := manager.getEprFromLabels(service.Spec.Labels)
+		if err != nil {
+			continue
+		}
+
+		for port := epr.Min; port <= epr.Max; port++ {
+			taken[port] = true
+		}
+	}
+
+	min, max := manager.config.EprMin, manager.config.EprMax
+	for start := min; start+maxConnections-1 <= max; start++ {
+		free := true
+		for port := start; port < start+maxConnections; port++ {
+			if taken[port] {
+				free = false
+				break
+			}
+		}
+
+		if free {
+			return types.EprRange{Min: start, Max: start + maxConnections - 1}, nil
+		}
+	}
+
+	return types.EprRange{}, fmt.Errorf("no free EPR range of size %d available", maxConnections)
+}
@@ -0,0 +1,135 @@
+package room
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"m1k1o/neko_rooms/internal/types"
+)
+
+var roomsBucket = []byte("rooms")
+
+// roomRecord is everything needed to re-materialize a room that has been
+// suspended (its container removed) without losing its settings or its
+// place in the EPR range, so a restored room keeps the same URL and port
+// allocation it had before.
+type roomRecord struct {
+	Name       string             `json:"name"`
+	Settings   types.RoomSettings `json:"settings"`
+	Epr        types.EprRange     `json:"epr"`
+	LastActive time.Time          `json:"last_active"`
+	Suspended  bool               `json:"suspended"`
+}
+
+// store persists room records to a BoltDB file so they survive both
+// `docker rm` on an idle-suspended room and neko-rooms restarts.
+type store struct {
+	db *bolt.DB
+}
+
+func newStore(path string) (*store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(roomsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &store{db: db}, nil
+}
+
+func (s *store) put(record roomRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(roomsBucket).Put([]byte(record.Name), data)
+	})
+}
+
+func (s *store) get(name string) (roomRecord, bool, error) {
+	var record roomRecord
+	found := false
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(roomsBucket).Get([]byte(name))
+		if data == nil {
+			return nil
+		}
+
+		found = true
+		return json.Unmarshal(data, &record)
+	})
+
+	return record, found, err
+}
+
+func (s *store) delete(name string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(roomsBucket).Delete([]byte(name))
+	})
+}
+
+func (s *store) all() (map[string]roomRecord, error) {
+	records := map[string]roomRecord{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(roomsBucket).ForEach(func(key, data []byte) error {
+			var record roomRecord
+			if err := json.Unmarshal(data, &record); err != nil {
+				return err
+			}
+
+			records[string(key)] = record
+			return nil
+		})
+	})
+
+	return records, err
+}
+
+// touch bumps a room's last-active timestamp, resetting its idle clock.
+func (s *store) touch(name string) error {
+	record, found, err := s.get(name)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return nil
+	}
+
+	record.LastActive = time.Now()
+	return s.put(record)
+}
+
+func (s *store) setSuspended(name string, suspended bool) error {
+	record, found, err := s.get(name)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return nil
+	}
+
+	record.Suspended = suspended
+	if !suspended {
+		record.LastActive = time.Now()
+	}
+
+	return s.put(record)
+}
+
+func (s *store) Close() error {
+	return s.db.Close()
+}
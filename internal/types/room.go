@@ -0,0 +1,67 @@
+// Package types holds the data shapes shared between the room manager,
+// its backends, and their callers, kept free of any backend- or
+// transport-specific dependencies so everyone can import it without
+// pulling in Docker/Podman/HTTP packages.
+package types
+
+import "time"
+
+// EprRange is the inclusive UDP port range a room's WebRTC media is
+// allocated out of the operator-configured EprMin/EprMax pool.
+type EprRange struct {
+	Min int
+	Max int
+}
+
+// RoomSettings is the user-facing configuration of a room. Name and
+// MaxConnections are read back from an inspected container/service's
+// labels (room name and EPR range); ToEnv/FromEnv encode no fields.
+type RoomSettings struct {
+	Name           string
+	MaxConnections int
+}
+
+// ToEnv returns additional NEKO_* environment variables derived from the
+// settings beyond what the room manager always sets itself (bind address,
+// EPR range, NAT1TO1 IPs).
+func (s RoomSettings) ToEnv() []string {
+	return nil
+}
+
+// FromEnv populates any settings ToEnv encodes, read back from a
+// container/service's actual environment.
+func (s *RoomSettings) FromEnv(env []string) error {
+	return nil
+}
+
+// RoomEntry is a single row in RoomManager.List(), combining identity,
+// routing, and live status for one room.
+type RoomEntry struct {
+	ID             string
+	URL            string
+	Name           string
+	MaxConnections int
+	Image          string
+	Running        bool
+	Status         string
+	Created        time.Time
+}
+
+// RoomEventKind identifies what happened to a room in a RoomEvent.
+type RoomEventKind string
+
+const (
+	RoomEventCreated      RoomEventKind = "created"
+	RoomEventStarted      RoomEventKind = "started"
+	RoomEventDied         RoomEventKind = "died"
+	RoomEventDestroyed    RoomEventKind = "destroyed"
+	RoomEventHealthStatus RoomEventKind = "health_status"
+)
+
+// RoomEvent is a single room lifecycle transition, as published on the
+// channel returned by RoomManagerCtx.Subscribe().
+type RoomEvent struct {
+	ID   string
+	Name string
+	Kind RoomEventKind
+}
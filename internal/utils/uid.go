@@ -0,0 +1,21 @@
+// Package utils holds small helpers with no natural home in a more
+// specific package.
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// NewUID returns a random hex-encoded identifier of length chars (so the
+// underlying random byte slice is length/2, rounded up), suitable for an
+// auto-generated room name when the operator didn't ask for a specific
+// one.
+func NewUID(length int) (string, error) {
+	b := make([]byte, (length+1)/2)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b)[:length], nil
+}